@@ -0,0 +1,119 @@
+// Copyright 2018 Capital One Services, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package servicectl
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Kind identifies which init system manages services on the host.
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindSystemd
+	KindUpstart
+	KindSysV
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindSystemd:
+		return "systemd"
+	case KindUpstart:
+		return "upstart"
+	case KindSysV:
+		return "sysV"
+	default:
+		return "unknown"
+	}
+}
+
+// Detect probes the host for its init system, so callers can log or
+// branch on the result without re-running the underlying probes
+// themselves.
+func Detect() (Kind, error) {
+	switch {
+	case isSystemd():
+		return KindSystemd, nil
+	case isUpstart():
+		return KindUpstart, nil
+	case isSysV():
+		return KindSysV, nil
+	}
+	return KindUnknown, errors.Errorf("cannot detect service manager")
+}
+
+// isSystemd reports whether systemd is managing services on the host.
+// /run/systemd/system is the usual signal, but it is absent in some
+// containers and chroots, so we also consult /proc/1/comm and finally
+// fall back to asking systemctl directly.
+func isSystemd() bool {
+	if _, err := os.Stat("/run/systemd/system"); err == nil {
+		return true
+	}
+	if comm, err := ioutil.ReadFile("/proc/1/comm"); err == nil {
+		if strings.TrimSpace(string(comm)) == "systemd" {
+			return true
+		}
+	}
+	if cmd, err := exec.LookPath("systemctl"); err == nil {
+		if _, err := exec.Command(cmd, "is-system-running").CombinedOutput(); err == nil {
+			return true
+		} else if _, ok := err.(*exec.ExitError); ok {
+			// systemctl ran and reported a non-"running" state (e.g.
+			// "degraded" or "starting"); the binary still indicates
+			// systemd is in use.
+			return true
+		}
+	}
+	return false
+}
+
+func isSysV() bool {
+	if _, err := os.Stat("/usr/sbin/service"); err == nil {
+		return true
+	}
+	return false
+}
+
+// isUpstart reports whether upstart is managing services on the host.
+// /sbin/init --version is unreliable on modern distros where /sbin/init
+// is a symlink to systemd, so initctl is probed first.
+func isUpstart() bool {
+	if _, err := os.Stat("/sbin/upstart-udev-bridge"); err == nil {
+		return true
+	}
+	if out, err := exec.Command("/sbin/initctl", "--version").Output(); err == nil {
+		if strings.Contains(string(out), "init (upstart") {
+			return true
+		}
+	}
+	if _, err := os.Stat("/sbin/init"); err == nil {
+		if out, err := exec.Command("/sbin/init", "--version").Output(); err == nil {
+			if strings.Contains(string(out), "init (upstart") {
+				return true
+			}
+		}
+	}
+	return false
+}
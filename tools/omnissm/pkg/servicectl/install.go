@@ -0,0 +1,37 @@
+// Copyright 2018 Capital One Services, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servicectl
+
+// InstallConfig describes how a service should be registered with the
+// underlying init system. Fields that do not apply to a given backend
+// (e.g. LimitNOFILE outside of systemd) are ignored. The same
+// InstallConfig is used across all supported backends (systemd, upstart,
+// sysV, rc.d, launchd).
+type InstallConfig struct {
+	DisplayName  string
+	Description  string
+	Executable   string
+	Arguments    []string
+	WorkingDir   string
+	User         string
+	Environment  map[string]string
+	Dependencies []string
+	Restart      string
+	PIDFile      string
+
+	// LimitNOFILE sets the systemd unit's open file descriptor limit. It
+	// has no effect on other backends.
+	LimitNOFILE int
+}
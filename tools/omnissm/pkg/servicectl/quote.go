@@ -0,0 +1,67 @@
+// Copyright 2018 Capital One Services, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servicectl
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// shQuote single-quotes s for safe interpolation into a POSIX shell
+// command line, as used by the sysV and rc.d templates, escaping any
+// embedded single quotes.
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shQuoteJoin shell-quotes each of args individually and joins them with
+// a space, so that a later unquoted re-expansion (as rc.subr does with
+// command_args, or su -c with its own command line) still treats each
+// argument, including ones containing spaces, as a single token.
+func shQuoteJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// systemdQuote quotes s for use inside a systemd unit file's
+// Environment=/ExecStart= directives, per systemd.syntax(7)'s C-style
+// escaping. Values with no characters that need escaping are left bare.
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'$\\") {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\', '$':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// xmlEscape escapes s for safe inclusion as character data in the
+// launchd plist rendered by launchdPlistTemplate.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 Capital One Services, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servicectl
+
+import (
+	"context"
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Runner executes a single command against the init system and returns
+// its combined output. It is the extension point that lets a backend
+// shell out (execRunner, the default), replay canned responses in tests
+// (fakeRunner), or, for systemd, skip forking a process entirely
+// (dbusRunner, in dbus_linux.go).
+type Runner interface {
+	Run(ctx context.Context, cmd string, args ...string) ([]byte, error)
+}
+
+// execRunner runs commands via os/exec. It is the default Runner.
+type execRunner struct{}
+
+func (execRunner) Run(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, cmd, args...).CombinedOutput()
+	if err != nil {
+		return out, errors.Wrapf(err, "%s command failed", cmd)
+	}
+	return out, nil
+}
+
+// defaultRunner returns r, or execRunner{} if r is nil.
+func defaultRunner(r Runner) Runner {
+	if r != nil {
+		return r
+	}
+	return execRunner{}
+}
+
+// fakeRunner is a Runner that records every invocation it receives and
+// replays a single canned response, so tests can exercise servicectl's
+// backends without a real init system present.
+type fakeRunner struct {
+	output []byte
+	err    error
+	calls  [][]string
+}
+
+func (f *fakeRunner) Run(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, append([]string{cmd}, args...))
+	return f.output, f.err
+}
+
+// Logger receives diagnostic output describing the commands servicectl
+// runs. The default, used when no WithLogger Option is given, discards
+// everything.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(string, ...interface{}) {}
+
+// backend holds the state shared by every init-system implementation of
+// Service.
+type backend struct {
+	cmd, name string
+	timeout   time.Duration
+	runner    Runner
+	logger    Logger
+}
+
+// run executes cmd through b's Runner, bounded by b's timeout. Most
+// backends call it with b.cmd (the binary New resolved for this init
+// system), but a few operations (e.g. sysV's use of update-rc.d) need a
+// different executable entirely, so cmd is not implied.
+func (b *backend) run(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, b.timeout)
+	defer cancel()
+	b.logger.Printf("servicectl: running %s %v", cmd, args)
+	return b.runner.Run(ctx, cmd, args...)
+}
@@ -12,140 +12,98 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package servicectl provides a small cross-platform wrapper for
+// starting, stopping, and installing an OS service, backed by whichever
+// init system is available (systemd, upstart, sysV, FreeBSD rc.d, or
+// macOS launchd).
 package servicectl
 
 import (
-	"os"
-	"os/exec"
-	"strings"
+	"context"
 	"time"
-
-	"github.com/pkg/errors"
 )
 
 type Service interface {
 	Start() error
 	Stop() error
 	Restart() error
-}
-
-func run(cmd string, args ...string) ([]byte, error) {
-	out, err := exec.Command(cmd, args...).CombinedOutput()
-	if err != nil {
-		return out, errors.Wrapf(err, "%s command failed", cmd)
-	}
-	return out, nil
-}
-
-type upstart struct {
-	cmd, name string
-}
-
-func (u *upstart) Start() error {
-	_, err := run(u.cmd, "start", u.name)
-	return err
-}
-func (u *upstart) Stop() error {
-	_, err := run(u.cmd, "stop", u.name)
-	return err
-}
-
-func (u *upstart) Restart() error {
-	// ignoring error for cases where Stop is called and the service is not
-	// already running
-	_ = u.Stop()
-	time.Sleep(50 * time.Millisecond)
-	return u.Start()
-}
-
-type systemd struct {
-	cmd, name string
-}
-
-func (s *systemd) Start() error {
-	_, err := run(s.cmd, "start", s.name)
-	return err
-}
-func (s *systemd) Stop() error {
-	_, err := run(s.cmd, "stop", s.name)
-	return err
-}
-
-func (s *systemd) Restart() error {
-	_, err := run(s.cmd, "restart", s.name)
-	return err
-}
-
-type sysV struct {
-	cmd, name string
-}
-
-func (s *sysV) Start() error {
-	_, err := run(s.cmd, s.name, "start")
-	return err
-}
-func (s *sysV) Stop() error {
-	_, err := run(s.cmd, s.name, "stop")
-	return err
-}
-
-func (s *sysV) Restart() error {
-	err := s.Stop()
-	if err != nil {
-		return err
+	// StartContext is Start, but bounded by ctx (and the timeout set via
+	// WithTimeout, if any) instead of running unbounded.
+	StartContext(ctx context.Context) error
+	// StopContext is Stop, but bounded by ctx.
+	StopContext(ctx context.Context) error
+	// RestartContext is Restart, but bounded by ctx.
+	RestartContext(ctx context.Context) error
+	// Status reports the current run state of the service.
+	Status() (Status, error)
+
+	// Install registers the service with the underlying init system,
+	// rendering a unit file/init script from cfg.
+	Install(cfg InstallConfig) error
+	// Uninstall removes the unit file/init script installed by Install.
+	Uninstall() error
+	// Enable marks the service to start automatically at boot.
+	Enable() error
+	// Disable reverses Enable.
+	Disable() error
+}
+
+// options holds the configuration built up by the Option values passed
+// to New.
+type options struct {
+	timeout time.Duration
+	runner  Runner
+	logger  Logger
+}
+
+// Option configures a Service returned by New.
+type Option func(*options)
+
+// WithTimeout bounds each command the returned Service runs against the
+// init system to d, on top of any deadline a caller-supplied
+// context.Context already carries. It does not bound an operation as a
+// whole: Restart's wait for the service to stop between its commands is
+// limited only by the context.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
 	}
-	time.Sleep(50 * time.Millisecond)
-	return s.Start()
 }
 
-func New(name string) (Service, error) {
-	switch {
-	case isSystemd():
-		cmd, err := exec.LookPath("systemctl")
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		return &systemd{cmd, name}, nil
-	case isUpstart():
-		cmd, err := exec.LookPath("initctl")
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		return &upstart{cmd, name}, nil
-	case isSysV():
-		cmd, err := exec.LookPath("service")
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		return &sysV{cmd, name}, nil
+// WithRunner overrides how the returned Service executes commands
+// against the underlying init system. It exists so callers (and this
+// package's own tests) can substitute a fakeRunner for a real one; most
+// callers should leave it unset and get each backend's default.
+func WithRunner(r Runner) Option {
+	return func(o *options) {
+		o.runner = r
 	}
-	return nil, errors.Errorf("cannot detect service manager")
 }
 
-func isSystemd() bool {
-	if _, err := os.Stat("/run/systemd/system"); err == nil {
-		return true
+// WithLogger routes diagnostic output about the commands servicectl runs
+// to l. The default is a no-op Logger.
+func WithLogger(l Logger) Option {
+	return func(o *options) {
+		o.logger = l
 	}
-	return false
 }
 
-func isSysV() bool {
-	if _, err := os.Stat("/usr/sbin/service"); err == nil {
-		return true
+// withTimeout derives a context bounded by d from ctx, unless d is zero
+// in which case ctx is returned unchanged. The returned cancel func is
+// always safe to defer.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
 	}
-	return false
+	return context.WithTimeout(ctx, d)
 }
 
-func isUpstart() bool {
-	if _, err := os.Stat("/sbin/upstart-udev-bridge"); err == nil {
-		return true
-	}
-	if _, err := os.Stat("/sbin/init"); err == nil {
-		if out, err := exec.Command("/sbin/init", "--version").Output(); err == nil {
-			if strings.Contains(string(out), "init (upstart") {
-				return true
-			}
-		}
+// New returns a Service for name, backed by whichever init system is
+// detected on the host.
+func New(name string, opts ...Option) (Service, error) {
+	o := options{logger: nopLogger{}}
+	for _, opt := range opts {
+		opt(&o)
 	}
-	return false
+	return newService(name, o)
 }
@@ -0,0 +1,170 @@
+// Copyright 2018 Capital One Services, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build darwin
+
+package servicectl
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// launchd implements Service on top of macOS's launchd.
+type launchd struct {
+	backend
+}
+
+func (l *launchd) label() string {
+	return "com." + l.name + ".daemon"
+}
+
+func (l *launchd) plistPath() string {
+	return "/Library/LaunchDaemons/" + l.label() + ".plist"
+}
+
+func (l *launchd) Start() error {
+	return l.StartContext(context.Background())
+}
+func (l *launchd) Stop() error {
+	return l.StopContext(context.Background())
+}
+func (l *launchd) Restart() error {
+	return l.RestartContext(context.Background())
+}
+
+func (l *launchd) StartContext(ctx context.Context) error {
+	_, err := l.run(ctx, l.cmd, "start", l.label())
+	return err
+}
+
+func (l *launchd) StopContext(ctx context.Context) error {
+	_, err := l.run(ctx, l.cmd, "stop", l.label())
+	return err
+}
+
+func (l *launchd) RestartContext(ctx context.Context) error {
+	_ = l.StopContext(ctx)
+	return l.StartContext(ctx)
+}
+
+func (l *launchd) Status() (Status, error) {
+	out, err := l.run(context.Background(), l.cmd, "list", l.label())
+	if err != nil {
+		return Status{State: StatusStopped}, nil
+	}
+	st := Status{State: StatusRunning}
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "\"PID\"") {
+			parts := strings.SplitN(line, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			val := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(parts[1]), ";"))
+			if pid, err := strconv.Atoi(val); err == nil {
+				st.PID = pid
+			}
+		}
+	}
+	return st, nil
+}
+
+func (l *launchd) Install(cfg InstallConfig) error {
+	f, err := os.Create(l.plistPath())
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", l.plistPath())
+	}
+	defer f.Close()
+	data := struct {
+		InstallConfig
+		Label string
+	}{cfg, l.label()}
+	if err := launchdPlistTemplate.Execute(f, data); err != nil {
+		return errors.Wrapf(err, "failed to render launchd plist for %s", l.name)
+	}
+	return nil
+}
+
+func (l *launchd) Uninstall() error {
+	if err := os.Remove(l.plistPath()); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove %s", l.plistPath())
+	}
+	return nil
+}
+
+func (l *launchd) Enable() error {
+	_, err := l.run(context.Background(), l.cmd, "load", "-w", l.plistPath())
+	return err
+}
+
+func (l *launchd) Disable() error {
+	_, err := l.run(context.Background(), l.cmd, "unload", "-w", l.plistPath())
+	return err
+}
+
+var launchdPlistTemplate = template.Must(template.New("launchd").Funcs(template.FuncMap{"esc": xmlEscape}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{esc .Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{esc .Executable}}</string>
+{{range .Arguments}}		<string>{{esc .}}</string>
+{{end}}	</array>
+{{if .WorkingDir}}	<key>WorkingDirectory</key>
+	<string>{{esc .WorkingDir}}</string>
+{{end}}{{if .User}}	<key>UserName</key>
+	<string>{{esc .User}}</string>
+{{end}}{{if .Environment}}	<key>EnvironmentVariables</key>
+	<dict>
+{{range $k, $v := .Environment}}		<key>{{esc $k}}</key>
+		<string>{{esc $v}}</string>
+{{end}}	</dict>
+{{end}}	<key>RunAtLoad</key>
+	<true/>
+{{if .Restart}}	<key>KeepAlive</key>
+	<true/>
+{{end}}</dict>
+</plist>
+`))
+
+func newService(name string, o options) (Service, error) {
+	if !isLaunchd() {
+		return nil, errors.Errorf("cannot detect service manager")
+	}
+	cmd, err := exec.LookPath("launchctl")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &launchd{backend{cmd, name, o.timeout, defaultRunner(o.runner), o.logger}}, nil
+}
+
+func isLaunchd() bool {
+	if _, err := os.Stat("/bin/launchctl"); err == nil {
+		return true
+	}
+	if _, err := exec.LookPath("launchctl"); err == nil {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+// Copyright 2018 Capital One Services, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build freebsd
+
+package servicectl
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// rcd implements Service on top of FreeBSD's rc.d scripts.
+type rcd struct {
+	backend
+}
+
+func (r *rcd) Start() error {
+	return r.StartContext(context.Background())
+}
+func (r *rcd) Stop() error {
+	return r.StopContext(context.Background())
+}
+func (r *rcd) Restart() error {
+	return r.RestartContext(context.Background())
+}
+
+func (r *rcd) StartContext(ctx context.Context) error {
+	_, err := r.run(ctx, r.cmd, r.name, "start")
+	return err
+}
+
+func (r *rcd) StopContext(ctx context.Context) error {
+	_, err := r.run(ctx, r.cmd, r.name, "stop")
+	return err
+}
+
+func (r *rcd) RestartContext(ctx context.Context) error {
+	_, err := r.run(ctx, r.cmd, r.name, "restart")
+	return err
+}
+
+func (r *rcd) Status() (Status, error) {
+	_, err := r.run(context.Background(), r.cmd, r.name, "status")
+	if err == nil {
+		return Status{State: StatusRunning}, nil
+	}
+	exitErr, ok := errors.Cause(err).(interface{ ExitCode() int })
+	if !ok {
+		return Status{State: StatusUnknown}, err
+	}
+	if exitErr.ExitCode() == 1 {
+		return Status{State: StatusStopped, ExitCode: 1}, nil
+	}
+	return Status{State: StatusUnknown, ExitCode: exitErr.ExitCode()}, err
+}
+
+func (r *rcd) Install(cfg InstallConfig) error {
+	f, err := os.Create(r.scriptPath())
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", r.scriptPath())
+	}
+	data := struct {
+		InstallConfig
+		Name string
+	}{cfg, r.name}
+	if err := rcdScriptTemplate.Execute(f, data); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to render rc.d script for %s", r.name)
+	}
+	f.Close()
+	if err := os.Chmod(r.scriptPath(), 0755); err != nil {
+		return errors.Wrapf(err, "failed to mark %s executable", r.scriptPath())
+	}
+	return nil
+}
+
+func (r *rcd) Uninstall() error {
+	if err := os.Remove(r.scriptPath()); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove %s", r.scriptPath())
+	}
+	return nil
+}
+
+func (r *rcd) Enable() error {
+	_, err := r.run(context.Background(), "sysrc", r.name+"_enable=YES")
+	return err
+}
+
+func (r *rcd) Disable() error {
+	_, err := r.run(context.Background(), "sysrc", r.name+"_enable=NO")
+	return err
+}
+
+func (r *rcd) scriptPath() string {
+	return "/usr/local/etc/rc.d/" + r.name
+}
+
+var rcdScriptTemplate = template.Must(template.New("rcd").Funcs(template.FuncMap{
+	"quote":       shQuote,
+	"shQuoteJoin": shQuoteJoin,
+}).Parse(`#!/bin/sh
+#
+# PROVIDE: {{.DisplayName}}
+# REQUIRE: {{range .Dependencies}}{{.}} {{end}}LOGIN
+# KEYWORD: shutdown
+
+. /etc/rc.subr
+
+name={{quote .Name}}
+rcvar={{quote (printf "%s_enable" .Name)}}
+{{if .PIDFile}}pidfile={{quote .PIDFile}}
+{{end}}{{range $k, $v := .Environment}}{{$k}}={{quote $v}}
+export {{$k}}
+{{end}}
+command={{quote .Executable}}
+command_args="{{shQuoteJoin .Arguments}}"
+{{if .User}}command_user={{quote .User}}
+{{end}}
+load_rc_config $name
+run_rc_command "$1"
+`))
+
+func newService(name string, o options) (Service, error) {
+	if !isRCD() {
+		return nil, errors.Errorf("cannot detect service manager")
+	}
+	cmd, err := exec.LookPath("service")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &rcd{backend{cmd, name, o.timeout, defaultRunner(o.runner), o.logger}}, nil
+}
+
+func isRCD() bool {
+	if _, err := os.Stat("/etc/rc.subr"); err == nil {
+		return true
+	}
+	return false
+}
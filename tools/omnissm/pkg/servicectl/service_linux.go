@@ -0,0 +1,456 @@
+// Copyright 2018 Capital One Services, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package servicectl
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// pollInterval is how often Restart polls Status() while waiting for a
+// stopped service to settle before starting it again.
+const pollInterval = 50 * time.Millisecond
+
+type upstart struct {
+	backend
+}
+
+func (u *upstart) Start() error {
+	return u.StartContext(context.Background())
+}
+func (u *upstart) Stop() error {
+	return u.StopContext(context.Background())
+}
+func (u *upstart) Restart() error {
+	return u.RestartContext(context.Background())
+}
+
+func (u *upstart) StartContext(ctx context.Context) error {
+	_, err := u.run(ctx, u.cmd, "start", u.name)
+	return err
+}
+
+func (u *upstart) StopContext(ctx context.Context) error {
+	_, err := u.run(ctx, u.cmd, "stop", u.name)
+	return err
+}
+
+func (u *upstart) RestartContext(ctx context.Context) error {
+	// ignoring error for cases where Stop is called and the service is not
+	// already running
+	_ = u.StopContext(ctx)
+	if err := pollUntilStopped(ctx, u); err != nil {
+		return err
+	}
+	return u.StartContext(ctx)
+}
+
+func (u *upstart) Status() (Status, error) {
+	out, err := u.run(context.Background(), u.cmd, "status", u.name)
+	if err != nil {
+		return Status{State: StatusUnknown}, err
+	}
+	line := strings.TrimSpace(string(out))
+	st := Status{State: StatusUnknown}
+	switch {
+	case strings.Contains(line, "start/running"):
+		st.State = StatusRunning
+	case strings.Contains(line, "stop/waiting"):
+		st.State = StatusStopped
+	}
+	if idx := strings.Index(line, "process "); idx != -1 {
+		if pid, err := strconv.Atoi(strings.TrimSpace(line[idx+len("process "):])); err == nil {
+			st.PID = pid
+		}
+	}
+	return st, nil
+}
+
+func (u *upstart) Install(cfg InstallConfig) error {
+	f, err := os.Create(u.confPath())
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", u.confPath())
+	}
+	defer f.Close()
+	if err := upstartConfTemplate.Execute(f, cfg); err != nil {
+		return errors.Wrapf(err, "failed to render upstart conf for %s", u.name)
+	}
+	return nil
+}
+
+func (u *upstart) Uninstall() error {
+	if err := os.Remove(u.confPath()); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove %s", u.confPath())
+	}
+	return nil
+}
+
+func (u *upstart) Enable() error {
+	_, err := u.run(context.Background(), u.cmd, "reload-configuration")
+	return err
+}
+
+func (u *upstart) Disable() error {
+	if err := os.Remove(u.confPath()); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove %s", u.confPath())
+	}
+	_, err := u.run(context.Background(), u.cmd, "reload-configuration")
+	return err
+}
+
+func (u *upstart) confPath() string {
+	return "/etc/init/" + u.name + ".conf"
+}
+
+type systemd struct {
+	backend
+}
+
+func (s *systemd) Start() error {
+	return s.StartContext(context.Background())
+}
+func (s *systemd) Stop() error {
+	return s.StopContext(context.Background())
+}
+func (s *systemd) Restart() error {
+	return s.RestartContext(context.Background())
+}
+
+func (s *systemd) StartContext(ctx context.Context) error {
+	_, err := s.run(ctx, s.cmd, "start", s.name)
+	return err
+}
+
+func (s *systemd) StopContext(ctx context.Context) error {
+	_, err := s.run(ctx, s.cmd, "stop", s.name)
+	return err
+}
+
+func (s *systemd) RestartContext(ctx context.Context) error {
+	_, err := s.run(ctx, s.cmd, "restart", s.name)
+	return err
+}
+
+func (s *systemd) Status() (Status, error) {
+	out, err := s.run(context.Background(), s.cmd, "show", s.name, "--property=ActiveState,MainPID,ExecMainStatus")
+	if err != nil {
+		return Status{State: StatusUnknown}, err
+	}
+	props := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		props[kv[0]] = kv[1]
+	}
+	st := Status{State: StatusUnknown}
+	switch props["ActiveState"] {
+	case "active", "activating", "reloading":
+		st.State = StatusRunning
+	case "inactive", "failed", "deactivating":
+		st.State = StatusStopped
+	}
+	if pid, err := strconv.Atoi(props["MainPID"]); err == nil {
+		st.PID = pid
+	}
+	if code, err := strconv.Atoi(props["ExecMainStatus"]); err == nil {
+		st.ExitCode = code
+	}
+	return st, nil
+}
+
+func (s *systemd) Install(cfg InstallConfig) error {
+	f, err := os.Create(s.unitPath())
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", s.unitPath())
+	}
+	defer f.Close()
+	if err := systemdUnitTemplate.Execute(f, cfg); err != nil {
+		return errors.Wrapf(err, "failed to render systemd unit for %s", s.name)
+	}
+	if _, err := s.run(context.Background(), s.cmd, "daemon-reload"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *systemd) Uninstall() error {
+	if err := os.Remove(s.unitPath()); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove %s", s.unitPath())
+	}
+	_, err := s.run(context.Background(), s.cmd, "daemon-reload")
+	return err
+}
+
+func (s *systemd) Enable() error {
+	_, err := s.run(context.Background(), s.cmd, "enable", s.name)
+	return err
+}
+
+func (s *systemd) Disable() error {
+	_, err := s.run(context.Background(), s.cmd, "disable", s.name)
+	return err
+}
+
+func (s *systemd) unitPath() string {
+	return "/etc/systemd/system/" + s.name + ".service"
+}
+
+type sysV struct {
+	backend
+}
+
+func (s *sysV) Start() error {
+	return s.StartContext(context.Background())
+}
+func (s *sysV) Stop() error {
+	return s.StopContext(context.Background())
+}
+func (s *sysV) Restart() error {
+	return s.RestartContext(context.Background())
+}
+
+func (s *sysV) StartContext(ctx context.Context) error {
+	_, err := s.run(ctx, s.cmd, s.name, "start")
+	return err
+}
+
+func (s *sysV) StopContext(ctx context.Context) error {
+	_, err := s.run(ctx, s.cmd, s.name, "stop")
+	return err
+}
+
+func (s *sysV) RestartContext(ctx context.Context) error {
+	if err := s.StopContext(ctx); err != nil {
+		return err
+	}
+	if err := pollUntilStopped(ctx, s); err != nil {
+		return err
+	}
+	return s.StartContext(ctx)
+}
+
+func (s *sysV) Status() (Status, error) {
+	out, err := s.run(context.Background(), s.cmd, s.name, "status")
+	st := Status{State: StatusUnknown}
+	if err == nil {
+		st.State = StatusRunning
+		return st, nil
+	}
+	exitErr, ok := errors.Cause(err).(interface{ ExitCode() int })
+	if !ok {
+		return st, err
+	}
+	switch exitErr.ExitCode() {
+	case 3:
+		st.State = StatusStopped
+		st.ExitCode = 3
+		return st, nil
+	default:
+		st.ExitCode = exitErr.ExitCode()
+		return st, errors.Wrapf(err, "%s", strings.TrimSpace(string(out)))
+	}
+}
+
+func (s *sysV) Install(cfg InstallConfig) error {
+	f, err := os.Create(s.scriptPath())
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", s.scriptPath())
+	}
+	if err := sysVInitTemplate.Execute(f, cfg); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to render init script for %s", s.name)
+	}
+	f.Close()
+	if err := os.Chmod(s.scriptPath(), 0755); err != nil {
+		return errors.Wrapf(err, "failed to mark %s executable", s.scriptPath())
+	}
+	return nil
+}
+
+func (s *sysV) Uninstall() error {
+	if err := os.Remove(s.scriptPath()); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove %s", s.scriptPath())
+	}
+	return nil
+}
+
+func (s *sysV) Enable() error {
+	_, err := s.run(context.Background(), "update-rc.d", s.name, "defaults")
+	return err
+}
+
+func (s *sysV) Disable() error {
+	_, err := s.run(context.Background(), "update-rc.d", s.name, "remove")
+	return err
+}
+
+func (s *sysV) scriptPath() string {
+	return "/etc/init.d/" + s.name
+}
+
+// pollUntilStopped polls svc.Status() at pollInterval until a successful
+// call reports StatusStopped (or StatusUnknown, meaning the backend can't
+// tell and we should not block Restart on it), or ctx is done. A failing
+// Status() call is not taken as evidence the service has stopped - it is
+// retried like any other in-progress poll, so a transient error (a timed
+// out systemctl/initctl call, say) can't make Restart start a new
+// instance while the old one may still be running.
+func pollUntilStopped(ctx context.Context, svc interface{ Status() (Status, error) }) error {
+	for {
+		st, err := svc.Status()
+		if err == nil && st.State != StatusRunning {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+var systemdUnitTemplate = template.Must(template.New("systemd").Funcs(template.FuncMap{"quote": systemdQuote}).Parse(`[Unit]
+Description={{.Description}}
+{{range .Dependencies}}After={{.}}
+{{end}}
+[Service]
+{{if .WorkingDir}}WorkingDirectory={{.WorkingDir}}
+{{end}}{{if .User}}User={{.User}}
+{{end}}{{range $k, $v := .Environment}}Environment={{quote (printf "%s=%s" $k $v)}}
+{{end}}ExecStart={{quote .Executable}}{{range .Arguments}} {{quote .}}{{end}}
+{{if .Restart}}Restart={{.Restart}}
+{{end}}{{if .PIDFile}}PIDFile={{.PIDFile}}
+{{end}}{{if .LimitNOFILE}}LimitNOFILE={{.LimitNOFILE}}
+{{end}}
+[Install]
+WantedBy=multi-user.target
+`))
+
+var upstartConfTemplate = template.Must(template.New("upstart").Funcs(template.FuncMap{"quote": shQuote}).Parse(`description "{{.Description}}"
+
+start on runlevel [2345]
+stop on runlevel [016]
+
+{{if .Restart}}respawn
+{{end}}{{range $k, $v := .Environment}}env {{quote (printf "%s=%s" $k $v)}}
+{{end}}
+exec {{quote .Executable}}{{range .Arguments}} {{quote .}}{{end}}
+`))
+
+// sysvStartCmd renders the shell command sysVInitTemplate's start case
+// runs: exe and args are individually shell-quoted so values containing
+// spaces or shell metacharacters can't split into extra arguments or
+// escape quoting, and, if pidFile is set, the command is backgrounded
+// with its PID recorded, matching how the LSB init script this package
+// generates starts a daemon.
+func sysvStartCmd(exe string, args []string, pidFile string) string {
+	cmd := shQuote(exe)
+	if joined := shQuoteJoin(args); joined != "" {
+		cmd += " " + joined
+	}
+	if pidFile != "" {
+		cmd += " & echo $! > " + shQuote(pidFile)
+	}
+	return cmd
+}
+
+var sysVInitTemplate = template.Must(template.New("sysV").Funcs(template.FuncMap{
+	"quote":        shQuote,
+	"sysvStartCmd": sysvStartCmd,
+}).Parse(`#!/bin/sh
+### BEGIN INIT INFO
+# Provides:          {{.DisplayName}}
+# Required-Start:    {{range .Dependencies}}{{.}} {{end}}
+# Required-Stop:     {{range .Dependencies}}{{.}} {{end}}
+# Default-Start:     2 3 4 5
+# Default-Stop:      0 1 6
+# Short-Description: {{.Description}}
+### END INIT INFO
+
+{{range $k, $v := .Environment}}export {{quote (printf "%s=%s" $k $v)}}
+{{end}}
+case "$1" in
+  start)
+    {{if .User}}su {{quote .User}} -c {{quote (sysvStartCmd .Executable .Arguments .PIDFile)}}{{else}}{{sysvStartCmd .Executable .Arguments .PIDFile}}{{end}}
+    ;;
+  stop)
+    {{if .PIDFile}}kill $(cat {{quote .PIDFile}}){{end}}
+    ;;
+  restart)
+    $0 stop
+    $0 start
+    ;;
+  status)
+    {{if .PIDFile}}if kill -0 $(cat {{quote .PIDFile}} 2>/dev/null) 2>/dev/null; then
+      exit 0
+    else
+      exit 3
+    fi{{else}}exit 3{{end}}
+    ;;
+  *)
+    echo "Usage: $0 {start|stop|restart|status}"
+    exit 1
+    ;;
+esac
+`))
+
+func newService(name string, o options) (Service, error) {
+	kind, err := Detect()
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case KindSystemd:
+		cmd, err := exec.LookPath("systemctl")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		runner := o.runner
+		if runner == nil {
+			// Prefer talking to systemd directly over D-Bus; fall back to
+			// shelling out to systemctl if that fails (e.g. no system bus).
+			if dr, err := newDBusRunner(); err == nil {
+				runner = dr
+			} else {
+				runner = execRunner{}
+			}
+		}
+		return &systemd{backend{cmd, name, o.timeout, runner, o.logger}}, nil
+	case KindUpstart:
+		cmd, err := exec.LookPath("initctl")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return &upstart{backend{cmd, name, o.timeout, defaultRunner(o.runner), o.logger}}, nil
+	case KindSysV:
+		cmd, err := exec.LookPath("service")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return &sysV{backend{cmd, name, o.timeout, defaultRunner(o.runner), o.logger}}, nil
+	}
+	return nil, errors.Errorf("cannot detect service manager")
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 Capital One Services, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package servicectl
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fakeExitError stands in for the *exec.ExitError a real execRunner
+// would return, so Status() parsing can be tested without shelling out.
+type fakeExitError struct {
+	code int
+}
+
+func (e *fakeExitError) Error() string { return "command exited non-zero" }
+func (e *fakeExitError) ExitCode() int { return e.code }
+
+func newTestBackend(name string, fr *fakeRunner) backend {
+	return backend{cmd: name + "ctl", name: name, runner: fr, logger: nopLogger{}}
+}
+
+func TestSystemdStartContextUsesRunner(t *testing.T) {
+	fr := &fakeRunner{}
+	svc := &systemd{newTestBackend("myapp", fr)}
+	if err := svc.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	want := [][]string{{"myappctl", "start", "myapp"}}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Fatalf("calls = %v, want %v", fr.calls, want)
+	}
+}
+
+func TestUpstartRestartContextPollsStatusBeforeStarting(t *testing.T) {
+	fr := &fakeRunner{output: []byte("myapp stop/waiting")}
+	svc := &upstart{newTestBackend("myapp", fr)}
+	if err := svc.Restart(); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	want := [][]string{
+		{"myappctl", "stop", "myapp"},
+		{"myappctl", "status", "myapp"},
+		{"myappctl", "start", "myapp"},
+	}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Fatalf("calls = %v, want %v", fr.calls, want)
+	}
+}
+
+func TestSysVStatusParsesLSBExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want State
+	}{
+		{"running", nil, StatusRunning},
+		{"stopped", &fakeExitError{code: 3}, StatusStopped},
+		{"other", &fakeExitError{code: 2}, StatusUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fr := &fakeRunner{err: tt.err}
+			svc := &sysV{newTestBackend("myapp", fr)}
+			st, err := svc.Status()
+			if tt.want != StatusUnknown && err != nil {
+				t.Fatalf("Status: %v", err)
+			}
+			if st.State != tt.want {
+				t.Fatalf("State = %v, want %v", st.State, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2018 Capital One Services, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servicectl
+
+// State represents the run state of a service as reported by the
+// underlying init system.
+type State int
+
+const (
+	StatusUnknown State = iota
+	StatusRunning
+	StatusStopped
+)
+
+func (s State) String() string {
+	switch s {
+	case StatusRunning:
+		return "running"
+	case StatusStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// Status describes the current run state of a service, along with its
+// PID when running and the exit code of its last run when known.
+type Status struct {
+	State    State
+	PID      int
+	ExitCode int
+}